@@ -0,0 +1,25 @@
+package main
+
+import "fmt"
+
+// OpError wraps a failure from a single step of processing one URL (URL
+// parsing, the native dumper, container create/start/logs/remove, post-dump
+// analysis) with enough context for the scheduler to decide whether the URL
+// is worth retrying.
+type OpError struct {
+	Op        string
+	URL       string
+	Err       error
+	Transient bool
+}
+
+func (e *OpError) Error() string {
+	return fmt.Sprintf("%s %s: %v", e.Op, e.URL, e.Err)
+}
+
+func (e *OpError) Unwrap() error { return e.Err }
+
+// Temporary reports whether the scheduler should retry this error. It
+// satisfies the `interface{ Temporary() bool }` the scheduler package
+// checks for.
+func (e *OpError) Temporary() bool { return e.Transient }
@@ -2,9 +2,7 @@ package main
 
 import (
 	"bufio"
-	"bytes"
 	"context"
-	_ "embed"
 	"encoding/json"
 	"errors"
 	"flag"
@@ -16,204 +14,304 @@ import (
 	"os/signal"
 	"path"
 	"path/filepath"
-	"regexp"
 	"strings"
-	"sync"
 	"syscall"
 
-	"github.com/docker/docker/api/types"
-	"github.com/docker/docker/api/types/container"
-	"github.com/docker/docker/api/types/mount"
-	"github.com/docker/docker/client"
+	"github.com/docker/docker/pkg/stdcopy"
+	"github.com/isolateminds/gget/pkg/analyze"
+	"github.com/isolateminds/gget/pkg/dumper"
+	"github.com/isolateminds/gget/pkg/events"
+	"github.com/isolateminds/gget/pkg/image"
+	"github.com/isolateminds/gget/pkg/runtime"
+	"github.com/isolateminds/gget/pkg/scheduler"
 	"github.com/ttacon/chalk"
 )
 
-//go:embed Dockerfile.tar
-var tarFile []byte
-var input, output, inputFilePath string
+var input, output, inputFilePath, backend, stateFile, logFormat, analyzeFlag, containerRuntime string
+var baseImage, aptProxy, httpProxy, pypiProxy, gitDumperRef string
+var concurrency int
+var noCache bool
 var inputFile *os.File
+var sink events.Sink
 var URLRegex = `https?:\/\/(www\.)?[-a-zA-Z0-9@:%._\+~#=]{1,256}\.[a-zA-Z0-9()]{1,6}\b([-a-zA-Z0-9()@:%_\+.~#?&//=]*)`
 
 func main() {
 	flag.StringVar(&input, "u", "", "git URL to download.")
 	flag.StringVar(&output, "o", "", "output directory")
 	flag.StringVar(&inputFilePath, "f", "", "A file of git url(s) seperated by new lines")
+	flag.StringVar(&backend, "backend", "docker", "dumping backend to use: docker|native")
+	flag.IntVar(&concurrency, "concurrency", 4, "number of URLs to process at once")
+	flag.StringVar(&stateFile, "state", "", "resumable state file recording completed URLs; re-run the same file to skip them")
+	flag.StringVar(&logFormat, "log-format", "tty", "how to render build/run events: tty|plain|json")
+	flag.StringVar(&baseImage, "base-image", "", "base image for the gget build image (default python:3.11-slim)")
+	flag.StringVar(&aptProxy, "apt-proxy", os.Getenv("APT_PROXY"), "apt proxy configured inside the image build")
+	flag.StringVar(&httpProxy, "http-proxy", os.Getenv("HTTP_PROXY"), "HTTP(S) proxy passed to the image build")
+	flag.StringVar(&pypiProxy, "pypi-proxy", os.Getenv("PYPI_PROXY"), "pip index URL used to install git-dumper")
+	flag.StringVar(&gitDumperRef, "git-dumper-ref", "", "git-dumper revision to pin in the image (default master)")
+	flag.BoolVar(&noCache, "no-cache", false, "force a fresh image build instead of reusing a cached one")
+	flag.StringVar(&analyzeFlag, "analyze", "", "comma-separated post-dump analyzers to run: secrets,deps,authors")
+	flag.StringVar(&containerRuntime, "runtime", "", "container runtime to use: docker|podman (default: auto-detect)")
 	flag.Parse()
 	HandleInput(&input, &inputFilePath)
 	HandleOutput(&output)
 
-	ctx, cancel := context.WithCancel(context.Background())
-	client, err := client.NewClientWithOpts(client.FromEnv)
+	if backend != "docker" && backend != "native" {
+		LogFatal("%s", "Unknown -backend, expected docker or native")
+	}
+
+	var err error
+	sink, err = events.New(logFormat)
 	if err != nil {
-		LogFatal("%s", "Unable to create client")
+		LogFatal("%s", err)
 	}
+
+	ctx, cancel := context.WithCancel(context.Background())
 	go HandleSIGTERM(func() {
-		//Upon SIGTERM delete output dir and cancel context
+		//Upon SIGTERM cancel context and, unless -state is set, delete
+		//the output dir. With -state, output holds completed dumps the
+		//state file promises to preserve across a killed/resumed run, so
+		//it must survive a Ctrl+C the same way it survives a crash.
 		cancel()
+		if stateFile != "" {
+			return
+		}
 		err := os.RemoveAll(output)
 		if err != nil {
 			LogFatal("%s", "Unable to remove output directory.")
 		}
 	})
 
-	BuildImage(ctx, client)
+	urls := []string{input}
 	if inputFile != nil {
-		var wg sync.WaitGroup
-		urls := make([]string, 0)
+		urls = urls[:0]
 		scanner := bufio.NewScanner(inputFile)
 		for scanner.Scan() {
 			urls = append(urls, scanner.Text())
 		}
-		wg.Add(len(urls))
-		for i := range urls {
-			go func(input string) {
-				RunContainerThenRemove(ctx, client, CreateContainer(ctx, client, input))
-				wg.Done()
-			}(urls[i])
-		}
-		wg.Wait()
-		inputFile.Close()
-	} else {
-		RunContainerThenRemove(ctx, client, CreateContainer(ctx, client, input))
+		defer inputFile.Close()
 	}
-}
 
-// An object that implements io.Writer for git dumper log
-type GitDumperLog struct {
-	URLRegex *regexp.Regexp
-}
+	reporter := scheduler.NewAggregateReporter()
 
-func (g *GitDumperLog) Write(p []byte) (int, error) {
-	if strings.Contains(string(p), "Fetching") {
-		fmt.Println(chalk.White.Color("(FETCHING)"), chalk.Green.Color(string(g.URLRegex.Find(p))))
-	} else if strings.Contains(string(p), "Testing") {
-		fmt.Println(chalk.White.Color("(TESTING)"), chalk.Yellow.Color(string(g.URLRegex.Find(p))))
+	var task scheduler.Task
+	if backend == "native" {
+		task = dumpNative(reporter)
 	} else {
-		fmt.Println(chalk.White.Color(string(p)))
+		rt, err := runtime.New(containerRuntime, output)
+		if err != nil {
+			LogFatal("%s", err)
+		}
+		tag := BuildImage(ctx, rt)
+		task = dumpContainer(rt, tag, reporter)
 	}
-	return len(p), nil
-}
-
-// Runs a  created container by the given id then removes
-func RunContainerThenRemove(ctxroot context.Context, client *client.Client, id string) {
 
-	err := client.ContainerStart(ctxroot, id, types.ContainerStartOptions{})
-	if err != nil {
-		LogFatal("%s", "Unable to start container", id, err)
-	}
-	rc, err := client.ContainerLogs(ctxroot, id, types.ContainerLogsOptions{
-		Follow:     true,
-		ShowStdout: true,
-		ShowStderr: true,
+	sched, err := scheduler.New(task, scheduler.Options{
+		Concurrency: concurrency,
+		StateFile:   stateFile,
+		Reporter:    reporter,
 	})
 	if err != nil {
-		LogFatal("%s", "Unable to follow container log output", err)
+		LogFatal("%s", err)
 	}
-	gdl := GitDumperLog{
-		URLRegex: regexp.MustCompile(URLRegex),
+	failed := sched.Run(ctx, urls)
+	fmt.Println()
+	if len(failed) > 0 {
+		LogFatal("%s", fmt.Sprintf("%d of %d URLs failed: %s", len(failed), len(urls), strings.Join(failed, ", ")))
 	}
-	io.Copy(&gdl, rc)
-
-	client.ContainerRemove(ctxroot, id, types.ContainerRemoveOptions{
-		RemoveVolumes: true,
-		Force:         true,
-	})
+}
 
+// hostnameFor returns the directory name gget stores a dumped repo under:
+// gitUrl's hostname with dots replaced by underscores.
+func hostnameFor(gitUrl string) (string, error) {
+	parsed, err := url.Parse(gitUrl)
 	if err != nil {
-		LogFatal("%s", "Unable to remove container", id, err)
+		return "", err
 	}
+	return strings.ReplaceAll(parsed.Hostname(), ".", "_"), nil
 }
 
-// Creates a contianer for gget to use
-func CreateContainer(ctx context.Context, client *client.Client, gitUrl string) (containerID string) {
-	url, err := url.Parse(gitUrl)
-	if err != nil {
-		LogFatal("%s", "Unable to parse git URL ", err)
+// dumpNative returns a scheduler.Task that dumps gitUrl with the native
+// backend, reporting status transitions to reporter.
+func dumpNative(reporter scheduler.Reporter) scheduler.Task {
+	return func(ctx context.Context, gitUrl string) error {
+		hostname, err := hostnameFor(gitUrl)
+		if err != nil {
+			return &OpError{Op: "url.Parse", URL: gitUrl, Err: err, Transient: false}
+		}
+		repoDir := filepath.Join(output, hostname)
+		d, err := dumper.New(dumper.Options{
+			BaseURL:   gitUrl,
+			OutputDir: repoDir,
+		})
+		if err != nil {
+			return &OpError{Op: "dumper.New", URL: gitUrl, Err: err, Transient: false}
+		}
+		if err := d.Dump(); err != nil {
+			return &OpError{Op: "dumper.Dump", URL: gitUrl, Err: err, Transient: isDumpTransient(err)}
+		}
+		// The bulk transfer is done; what's left is picking apart what
+		// we recovered with the -analyze analyzers.
+		reporter.Report(gitUrl, scheduler.StatusTesting)
+		return runAnalysis(ctx, gitUrl, repoDir)
 	}
-	hostname := strings.ReplaceAll(url.Hostname(), ".", "_")
-	body, err := client.ContainerCreate(
-		ctx,
-		&container.Config{
-			Image:        "gget",
-			AttachStdout: true,
-			AttachStderr: true,
-			User:         "gget",
-			//The entrypoint here is actually the execution of the git-dumper command
-			Cmd: []string{"git-dumper", gitUrl, fmt.Sprintf("/home/gget/%s", hostname)},
-		},
-		&container.HostConfig{
-			Mounts: []mount.Mount{
-				{
-					Type:   mount.TypeBind,
-					Source: output,
-					Target: "/home/gget",
-				},
-			},
-		},
-		nil,
-		nil,
-		hostname,
-	)
+}
 
-	if err != nil {
-		LogFatal("%s", "Unable to create a container", err)
+// isDumpTransient reports whether a dumper.Dump failure is worth retrying.
+// A dumper.StatusError carries its own verdict (a 404 on HEAD means the
+// host never exposed .git, not a blip); anything else - network errors,
+// git fsck/checkout failures on a half-fetched repo - defaults to
+// retryable, matching the Docker path's classification of errors it can't
+// otherwise identify.
+func isDumpTransient(err error) bool {
+	var se *dumper.StatusError
+	if errors.As(err, &se) {
+		return se.Temporary()
 	}
-	return body.ID
+	return true
 }
 
-// ImagePullResponse represents the output from docker's image build response.
-// that implements io.Writer
-type ImageBuildResponse struct {
-	Stream      string      `json:"stream"`
-	Status      string      `json:"status"`
-	Progress    string      `json:"progress"`
-	Aux         Aux         `json:"aux"`
-	ErrorDetail ErrorDetail `json:"errorDetail"`
-	Error       string      `json:"error"`
-}
-type Aux struct {
-	ID string `json:"id"`
+// dumpContainer returns a scheduler.Task that dumps gitUrl inside a
+// throwaway container running the given image tag, on whichever
+// ContainerRuntime rt wraps (Docker or Podman), reporting status
+// transitions to reporter.
+func dumpContainer(rt runtime.ContainerRuntime, tag string, reporter scheduler.Reporter) scheduler.Task {
+	return func(ctx context.Context, gitUrl string) error {
+		hostname, err := hostnameFor(gitUrl)
+		if err != nil {
+			return &OpError{Op: "url.Parse", URL: gitUrl, Err: err, Transient: false}
+		}
+		id, err := rt.CreateContainer(ctx, tag, hostname, gitUrl)
+		if err != nil {
+			return &OpError{Op: "CreateContainer", URL: gitUrl, Err: err, Transient: true}
+		}
+		if err := RunContainerThenRemove(ctx, rt, id, gitUrl); err != nil {
+			return err
+		}
+		// The bulk transfer is done; what's left is picking apart what
+		// we recovered with the -analyze analyzers.
+		reporter.Report(gitUrl, scheduler.StatusTesting)
+		return runAnalysis(ctx, gitUrl, filepath.Join(output, hostname))
+	}
 }
-type ErrorDetail struct {
-	Message string `json:"message"`
+
+// runAnalysis runs the -analyze analyzers over a successfully dumped repo
+// and writes their findings to <repoDir>/.gget-report.json. A no-op when
+// -analyze wasn't set.
+func runAnalysis(ctx context.Context, gitUrl string, repoDir string) error {
+	if analyzeFlag == "" {
+		return nil
+	}
+	analyzers, err := analyze.Resolve(strings.Split(analyzeFlag, ","))
+	if err != nil {
+		return &OpError{Op: "analyze.Resolve", URL: gitUrl, Err: err, Transient: false}
+	}
+	report := analyze.Run(ctx, gitUrl, repoDir, analyzers)
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return &OpError{Op: "analyze: marshal report", URL: gitUrl, Err: err, Transient: false}
+	}
+	if err := os.WriteFile(filepath.Join(repoDir, ".gget-report.json"), data, 0o644); err != nil {
+		return &OpError{Op: "analyze: write report", URL: gitUrl, Err: err, Transient: false}
+	}
+	return nil
 }
 
-func (ib *ImageBuildResponse) Write(p []byte) (int, error) {
-	jd := json.NewDecoder(bytes.NewReader(p))
-	err := jd.Decode(&ib)
-	if ib.Error != "" {
-		LogFatal("%s", ib.ErrorDetail.Message)
+// Runs a created container by id then removes it once git-dumper exits.
+// The container's combined log stream is demultiplexed into separate
+// stdout/stderr lines so git-dumper's stderr progress output isn't
+// interleaved byte-for-byte with its stdout.
+func RunContainerThenRemove(ctx context.Context, rt runtime.ContainerRuntime, id string, gitUrl string) error {
+	if err := rt.StartContainer(ctx, id); err != nil {
+		return &OpError{Op: "StartContainer", URL: gitUrl, Err: err, Transient: true}
 	}
-	if ib.Stream != "" {
-		fmt.Println(chalk.White.Color("(STREAM)"))
-		fmt.Println(chalk.Green.Color(ib.Stream))
+	rc, err := rt.Logs(ctx, id)
+	if err != nil {
+		return &OpError{Op: "Logs", URL: gitUrl, Err: err, Transient: true}
 	}
-	if ib.Progress != "" {
-		fmt.Println(chalk.White.Color("(STATUS)"))
-		fmt.Println(chalk.Green.Color(ib.Status))
+
+	stdoutR, stdoutW := io.Pipe()
+	stderrR, stderrW := io.Pipe()
+	go relayLines(stdoutR, func(line []byte) { sink.Stdout(gitUrl, line) })
+	go relayLines(stderrR, func(line []byte) { sink.Stderr(gitUrl, line) })
+
+	_, copyErr := stdcopy.StdCopy(stdoutW, stderrW, rc)
+	stdoutW.Close()
+	stderrW.Close()
+	rc.Close()
+	if copyErr != nil {
+		return &OpError{Op: "stdcopy.StdCopy", URL: gitUrl, Err: copyErr, Transient: true}
 	}
-	if ib.Status != "" {
-		fmt.Println(chalk.White.Color("(PROGRESS)"))
-		fmt.Println(chalk.Green.Color(ib.Progress))
+
+	if err := rt.Remove(ctx, id); err != nil {
+		return &OpError{Op: "Remove", URL: gitUrl, Err: err, Transient: true}
 	}
-	return len(p), err
+	return nil
 }
 
-// Build an image from embedded tar file
-func BuildImage(ctx context.Context, client *client.Client) {
-	var ibr ImageBuildResponse
-	options := types.ImageBuildOptions{
-		Tags: []string{"gget"},
+// relayLines scans r line by line, invoking emit for each, until r is
+// closed.
+func relayLines(r io.Reader, emit func(line []byte)) {
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		emit(scanner.Bytes())
 	}
-	res, err := client.ImageBuild(ctx, bytes.NewReader(tarFile), options)
+}
+
+// imageBuildEvent is one line of the newline-delimited JSON the container
+// runtime streams back from a build.
+type imageBuildEvent struct {
+	Stream   string `json:"stream"`
+	Status   string `json:"status"`
+	Progress string `json:"progress"`
+	Aux      struct {
+		ID string `json:"id"`
+	} `json:"aux"`
+	ErrorDetail struct {
+		Message string `json:"message"`
+	} `json:"errorDetail"`
+	Error string `json:"error"`
+}
+
+// BuildImage renders the gget worker image's Dockerfile from -base-image/
+// -apt-proxy/-http-proxy/-pypi-proxy/-git-dumper-ref, builds it on rt unless
+// a cached image with the same rendered content already exists, and streams
+// build events to the configured sink. It returns the tag the image was
+// built (or cached) under.
+func BuildImage(ctx context.Context, rt runtime.ContainerRuntime) string {
+	tag, body, err := rt.BuildImage(ctx, image.DockerfileOpt{
+		BaseImage:    baseImage,
+		AptProxy:     aptProxy,
+		HTTPProxy:    httpProxy,
+		HTTPSProxy:   httpProxy,
+		PypiProxy:    pypiProxy,
+		GitDumperRef: gitDumperRef,
+	}, noCache)
 	if err != nil {
 		LogFatal("%s", "Unable to build image", err)
 	}
-	//Discard written bytes
-	_, err = io.Copy(&ibr, res.Body)
-	if err != nil {
-		LogFatal("%s", "Unable to build copy build response", err)
+	defer body.Close()
+
+	dec := json.NewDecoder(body)
+	for {
+		var e imageBuildEvent
+		if err := dec.Decode(&e); err != nil {
+			if err == io.EOF {
+				break
+			}
+			LogFatal("%s", "Unable to decode build response", err)
+		}
+		if e.Error != "" {
+			sink.Build(events.BuildEvent{Error: e.Error, ErrorDetail: e.ErrorDetail.Message})
+			LogFatal("%s", e.ErrorDetail.Message)
+		}
+		sink.Build(events.BuildEvent{
+			Stream:   e.Stream,
+			Status:   e.Status,
+			Progress: e.Progress,
+			AuxID:    e.Aux.ID,
+		})
 	}
+	return tag
 }
 
 // Handles the input URL or file input
@@ -0,0 +1,65 @@
+// Package analyze runs a pluggable post-processing stage over a freshly
+// dumped repository: secret scanning, dependency inventory, and
+// commit-metadata extraction ship as built-ins, but callers can register
+// their own Analyzer.
+package analyze
+
+import (
+	"context"
+	"fmt"
+)
+
+// Analyzer inspects a checked-out repository at dir and returns its
+// findings as a JSON-marshalable value.
+type Analyzer interface {
+	Name() string
+	Analyze(ctx context.Context, dir string) (any, error)
+}
+
+// Report is written to <output>/<hostname>/.gget-report.json after a
+// successful dump.
+type Report struct {
+	Repo    string         `json:"repo"`
+	Results map[string]any `json:"results"`
+}
+
+// registry maps the names accepted by -analyze to their Analyzer.
+var registry = map[string]Analyzer{
+	"secrets": &SecretScanner{},
+	"deps":    &DependencyInventory{},
+	"authors": &CommitMetadataExtractor{},
+}
+
+// Register adds or replaces the Analyzer invoked by -analyze=name.
+func Register(name string, a Analyzer) {
+	registry[name] = a
+}
+
+// Resolve looks up each requested analyzer name.
+func Resolve(names []string) ([]Analyzer, error) {
+	analyzers := make([]Analyzer, 0, len(names))
+	for _, name := range names {
+		a, ok := registry[name]
+		if !ok {
+			return nil, fmt.Errorf("analyze: unknown analyzer %q", name)
+		}
+		analyzers = append(analyzers, a)
+	}
+	return analyzers, nil
+}
+
+// Run executes every analyzer over dir and collects their results into a
+// Report, keyed by analyzer name. An individual analyzer's error is
+// recorded under its name rather than aborting the rest of the report.
+func Run(ctx context.Context, repo string, dir string, analyzers []Analyzer) *Report {
+	report := &Report{Repo: repo, Results: make(map[string]any, len(analyzers))}
+	for _, a := range analyzers {
+		result, err := a.Analyze(ctx, dir)
+		if err != nil {
+			report.Results[a.Name()] = map[string]string{"error": err.Error()}
+			continue
+		}
+		report.Results[a.Name()] = result
+	}
+	return report
+}
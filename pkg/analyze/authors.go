@@ -0,0 +1,49 @@
+package analyze
+
+import (
+	"context"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// Commit is one entry from `git log`, reduced to what matters for an
+// authorship/activity summary.
+type Commit struct {
+	Email     string `json:"email"`
+	Timestamp int64  `json:"timestamp"`
+}
+
+// CommitMetadataExtractor lists author emails and commit timestamps across
+// every branch recovered from the dump.
+type CommitMetadataExtractor struct{}
+
+// Name implements Analyzer.
+func (c *CommitMetadataExtractor) Name() string { return "authors" }
+
+// Analyze implements Analyzer.
+func (c *CommitMetadataExtractor) Analyze(ctx context.Context, dir string) (any, error) {
+	cmd := exec.CommandContext(ctx, "git", "log", "--all", "--format=%ae|%at")
+	cmd.Dir = dir
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, err
+	}
+
+	var commits []Commit
+	for _, line := range strings.Split(strings.TrimSpace(string(out)), "\n") {
+		if line == "" {
+			continue
+		}
+		email, ts, ok := strings.Cut(line, "|")
+		if !ok {
+			continue
+		}
+		unix, err := strconv.ParseInt(ts, 10, 64)
+		if err != nil {
+			continue
+		}
+		commits = append(commits, Commit{Email: email, Timestamp: unix})
+	}
+	return commits, nil
+}
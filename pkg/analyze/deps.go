@@ -0,0 +1,78 @@
+package analyze
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+)
+
+// Package is one SBOM-style entry: the manifest that declared it and, when
+// cheaply available, a version string.
+type Package struct {
+	Ecosystem string `json:"ecosystem"`
+	File      string `json:"file"`
+	Name      string `json:"name,omitempty"`
+	Version   string `json:"version,omitempty"`
+}
+
+// depManifests maps a manifest filename to the ecosystem it identifies.
+// DependencyInventory records that each manifest exists and, for
+// package.json, also reads its name/version; the other formats need a real
+// lockfile parser to name individual dependencies, which is out of scope
+// here.
+var depManifests = map[string]string{
+	"package.json":     "npm",
+	"go.mod":           "go",
+	"requirements.txt": "pip",
+	"Gemfile.lock":     "bundler",
+	"pom.xml":          "maven",
+	"composer.json":    "composer",
+}
+
+// DependencyInventory walks the working tree for recognized manifest files
+// and emits an SBOM-style list of what it found.
+type DependencyInventory struct{}
+
+// Name implements Analyzer.
+func (d *DependencyInventory) Name() string { return "deps" }
+
+// Analyze implements Analyzer.
+func (d *DependencyInventory) Analyze(ctx context.Context, dir string) (any, error) {
+	var packages []Package
+	filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return nil
+		}
+		ecosystem, ok := depManifests[filepath.Base(path)]
+		if !ok {
+			return nil
+		}
+		rel, _ := filepath.Rel(dir, path)
+		pkg := Package{Ecosystem: ecosystem, File: rel}
+		if filepath.Base(path) == "package.json" {
+			pkg.Name, pkg.Version = readPackageJSON(path)
+		}
+		packages = append(packages, pkg)
+		return nil
+	})
+	return packages, nil
+}
+
+// readPackageJSON extracts the name/version fields from a package.json.
+// Errors are swallowed: a malformed manifest still counts as an npm
+// dependency, just one we couldn't name.
+func readPackageJSON(path string) (name, version string) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", ""
+	}
+	var manifest struct {
+		Name    string `json:"name"`
+		Version string `json:"version"`
+	}
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return "", ""
+	}
+	return manifest.Name, manifest.Version
+}
@@ -0,0 +1,125 @@
+package analyze
+
+import (
+	"bufio"
+	"context"
+	"math"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// Secret is a single match found by SecretScanner.
+type Secret struct {
+	Source string `json:"source"` // "tree" or "history"
+	File   string `json:"file,omitempty"`
+	Rule   string `json:"rule"`
+	Match  string `json:"match"`
+}
+
+// secretRules are regexes for common credential formats. Patterns are
+// intentionally permissive; false positives are expected and preferable to
+// missed secrets.
+var secretRules = map[string]*regexp.Regexp{
+	"aws_access_key":      regexp.MustCompile(`AKIA[0-9A-Z]{16}`),
+	"gcp_service_account": regexp.MustCompile(`"type"\s*:\s*"service_account"`),
+	"private_key":         regexp.MustCompile(`-----BEGIN (RSA|EC|OPENSSH|DSA|PGP) PRIVATE KEY-----`),
+	"jwt":                 regexp.MustCompile(`eyJ[A-Za-z0-9_-]+\.eyJ[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+`),
+	"slack_token":         regexp.MustCompile(`xox[baprs]-[0-9A-Za-z-]{10,}`),
+}
+
+// highEntropyRE finds base64/hex-looking substrings of at least 20
+// characters, which are then scored for Shannon entropy.
+var highEntropyRE = regexp.MustCompile(`[A-Za-z0-9+/=_-]{20,}`)
+
+// SecretScanner walks the checked-out working tree and git history blobs,
+// matching known credential formats plus generic high-entropy strings.
+type SecretScanner struct{}
+
+// Name implements Analyzer.
+func (s *SecretScanner) Name() string { return "secrets" }
+
+// Analyze implements Analyzer.
+func (s *SecretScanner) Analyze(ctx context.Context, dir string) (any, error) {
+	var secrets []Secret
+	secrets = append(secrets, scanTree(dir)...)
+	secrets = append(secrets, scanHistory(ctx, dir)...)
+	return secrets, nil
+}
+
+func scanTree(dir string) []Secret {
+	var secrets []Secret
+	filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() || strings.Contains(path, string(filepath.Separator)+".git"+string(filepath.Separator)) {
+			return nil
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil
+		}
+		rel, _ := filepath.Rel(dir, path)
+		for _, m := range findSecrets(string(data)) {
+			m.Source = "tree"
+			m.File = rel
+			secrets = append(secrets, m)
+		}
+		return nil
+	})
+	return secrets
+}
+
+// scanHistory scans every commit's patch text across all branches for
+// secrets that may have since been removed from the working tree.
+func scanHistory(ctx context.Context, dir string) []Secret {
+	var secrets []Secret
+	cmd := exec.CommandContext(ctx, "git", "log", "--all", "-p")
+	cmd.Dir = dir
+	out, err := cmd.Output()
+	if err != nil {
+		return nil
+	}
+	scanner := bufio.NewScanner(strings.NewReader(string(out)))
+	scanner.Buffer(make([]byte, 1024*1024), 1024*1024)
+	for scanner.Scan() {
+		for _, m := range findSecrets(scanner.Text()) {
+			m.Source = "history"
+			secrets = append(secrets, m)
+		}
+	}
+	return secrets
+}
+
+func findSecrets(content string) []Secret {
+	var secrets []Secret
+	for rule, re := range secretRules {
+		for _, match := range re.FindAllString(content, -1) {
+			secrets = append(secrets, Secret{Rule: rule, Match: match})
+		}
+	}
+	for _, candidate := range highEntropyRE.FindAllString(content, -1) {
+		if shannonEntropy(candidate) > 4.5 {
+			secrets = append(secrets, Secret{Rule: "high_entropy", Match: candidate})
+		}
+	}
+	return secrets
+}
+
+// shannonEntropy returns the Shannon entropy, in bits per character, of s.
+func shannonEntropy(s string) float64 {
+	if s == "" {
+		return 0
+	}
+	counts := make(map[rune]int)
+	for _, r := range s {
+		counts[r]++
+	}
+	var entropy float64
+	n := float64(len(s))
+	for _, c := range counts {
+		p := float64(c) / n
+		entropy -= p * math.Log2(p)
+	}
+	return entropy
+}
@@ -0,0 +1,122 @@
+// Package dumper implements a pure-Go re-implementation of git-dumper,
+// used to recover an exposed .git directory without needing Docker.
+package dumper
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Options configures a single dump run.
+type Options struct {
+	// BaseURL is the URL to the exposed .git directory's parent, e.g.
+	// https://example.com/ for https://example.com/.git/HEAD
+	BaseURL string
+	// OutputDir is the directory the recovered working tree is written to.
+	OutputDir string
+	// Client is the http.Client used for all requests. Defaults to an
+	// http.Client with a 30-second timeout when nil.
+	Client *http.Client
+}
+
+// Dumper recovers a git repository from an exposed .git directory served
+// over HTTP(S).
+type Dumper struct {
+	opts    Options
+	fetcher *fetcher
+	seen    map[string]bool
+}
+
+// New constructs a Dumper for the given options.
+func New(opts Options) (*Dumper, error) {
+	if opts.BaseURL == "" {
+		return nil, fmt.Errorf("dumper: BaseURL must not be empty")
+	}
+	if opts.OutputDir == "" {
+		return nil, fmt.Errorf("dumper: OutputDir must not be empty")
+	}
+	if opts.Client == nil {
+		opts.Client = &http.Client{Timeout: 30 * time.Second}
+	}
+	if !strings.HasSuffix(opts.BaseURL, "/") {
+		opts.BaseURL += "/"
+	}
+	return &Dumper{
+		opts:    opts,
+		fetcher: &fetcher{client: opts.Client, base: opts.BaseURL + ".git/"},
+		seen:    make(map[string]bool),
+	}, nil
+}
+
+// Dump fetches every reachable git object and metadata file, then
+// materializes a working tree in OutputDir. It returns an error instead of
+// exiting the process so callers (e.g. a scheduler) can classify failures.
+func (d *Dumper) Dump() error {
+	if err := os.MkdirAll(filepath.Join(d.opts.OutputDir, ".git"), 0o755); err != nil {
+		return fmt.Errorf("dumper: creating .git directory: %w", err)
+	}
+
+	for _, name := range []string{"HEAD", "config", "index", "packed-refs"} {
+		if err := d.fetchToFile(name); err != nil && name != "packed-refs" && name != "index" {
+			// packed-refs and index are optional (walkIndex copes with a
+			// missing index); HEAD and config are expected on almost
+			// every git-dumper-vulnerable server.
+			return fmt.Errorf("dumper: fetching %s: %w", name, err)
+		}
+	}
+
+	if err := d.walkRefs(); err != nil {
+		return fmt.Errorf("dumper: walking refs: %w", err)
+	}
+
+	if err := d.walkPackedRefs(); err != nil {
+		return fmt.Errorf("dumper: walking packed-refs: %w", err)
+	}
+
+	if err := d.walkLogs(); err != nil {
+		return fmt.Errorf("dumper: walking logs: %w", err)
+	}
+
+	if err := d.fetchPacks(); err != nil {
+		return fmt.Errorf("dumper: fetching packs: %w", err)
+	}
+
+	if err := d.walkIndex(); err != nil {
+		return fmt.Errorf("dumper: walking index: %w", err)
+	}
+
+	if seeds := d.headSHAs(); len(seeds) > 0 {
+		if err := d.walkObjects(seeds); err != nil {
+			return fmt.Errorf("dumper: walking objects: %w", err)
+		}
+	}
+
+	return d.checkout()
+}
+
+// checkout runs `git fsck` followed by `git checkout .` inside OutputDir to
+// materialize a working tree from the recovered .git directory.
+func (d *Dumper) checkout() error {
+	if err := d.runGit("fsck"); err != nil {
+		return fmt.Errorf("dumper: git fsck reported a corrupt or incomplete repository: %w", err)
+	}
+	if err := d.runGit("checkout", "."); err != nil {
+		return fmt.Errorf("dumper: git checkout: %w", err)
+	}
+	return nil
+}
+
+func (d *Dumper) runGit(args ...string) error {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = d.opts.OutputDir
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("%v: %s", err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
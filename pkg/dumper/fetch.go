@@ -0,0 +1,94 @@
+package dumper
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// fetcher retrieves files relative to a .git base URL. Writing them under
+// the local .git directory, mirroring the remote layout, is left to
+// Dumper.fetchToFile and fetchLooseObject.
+type fetcher struct {
+	client *http.Client
+	base   string
+}
+
+// get downloads base+name and returns its body. The caller must close it.
+func (f *fetcher) get(name string) (io.ReadCloser, error) {
+	res, err := f.client.Get(f.base + name)
+	if err != nil {
+		return nil, err
+	}
+	if res.StatusCode != http.StatusOK {
+		res.Body.Close()
+		return nil, &StatusError{Name: name, StatusCode: res.StatusCode}
+	}
+	return res.Body, nil
+}
+
+// StatusError reports a non-200 response to a fetch. It's most useful on
+// HEAD: a 404 there means the host doesn't expose .git at all, not a
+// network hiccup worth retrying.
+type StatusError struct {
+	Name       string
+	StatusCode int
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("unexpected status %d for %s", e.StatusCode, e.Name)
+}
+
+// Temporary reports whether the fetch might succeed if retried. A 4xx means
+// the path genuinely isn't there; a 5xx may be the server buckling under
+// load and worth another attempt.
+func (e *StatusError) Temporary() bool {
+	return e.StatusCode >= 500
+}
+
+// getBytes downloads base+name fully into memory.
+func (f *fetcher) getBytes(name string) ([]byte, error) {
+	rc, err := f.get(name)
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	return io.ReadAll(rc)
+}
+
+// fetchToFile downloads name into <OutputDir>/.git/<name>.
+func (d *Dumper) fetchToFile(name string) error {
+	dest, err := d.localPath(name)
+	if err != nil {
+		return err
+	}
+	data, err := d.fetcher.getBytes(name)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(dest), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(dest, data, 0o644)
+}
+
+// localPath resolves name against the local .git directory and rejects any
+// path that would escape it. name is never trustworthy: it comes verbatim
+// from HEAD, refs, logs, and objects/info/packs served by whatever host
+// we're dumping from, and a hostile one can return "../../../../home/x/.bashrc"
+// just as easily as a real ref name.
+func (d *Dumper) localPath(name string) (string, error) {
+	if filepath.IsAbs(name) {
+		return "", fmt.Errorf("refusing to write outside output directory: %q", name)
+	}
+	gitDir := filepath.Join(d.opts.OutputDir, ".git")
+	dest := filepath.Join(gitDir, filepath.FromSlash(name))
+	rel, err := filepath.Rel(gitDir, dest)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("refusing to write outside output directory: %q", name)
+	}
+	return dest, nil
+}
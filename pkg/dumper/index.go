@@ -0,0 +1,92 @@
+package dumper
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// indexEntry is the subset of a DIRC index entry gget cares about: the
+// blob SHA-1, used to seed the object walk.
+type indexEntry struct {
+	SHA1 string
+	Path string
+}
+
+// walkIndex parses the DIRC index fetched by Dump and queues every blob SHA
+// it references for download.
+func (d *Dumper) walkIndex() error {
+	path := filepath.Join(d.opts.OutputDir, ".git", "index")
+	data, err := os.ReadFile(path)
+	if err != nil {
+		// index is optional; servers that disable directory listing but
+		// still serve objects can be dumped from refs alone.
+		return nil
+	}
+	entries, err := parseIndex(data)
+	if err != nil {
+		return fmt.Errorf("parsing index: %w", err)
+	}
+	shas := make([]string, 0, len(entries))
+	for _, e := range entries {
+		shas = append(shas, e.SHA1)
+	}
+	return d.walkObjects(shas)
+}
+
+// parseIndex decodes a git index file (DIRC version 2 or 3) into its
+// entries. Version 4's path-compression scheme is not supported.
+func parseIndex(data []byte) ([]indexEntry, error) {
+	if len(data) < 12 || string(data[0:4]) != "DIRC" {
+		return nil, fmt.Errorf("not a git index (missing DIRC signature)")
+	}
+	version := binary.BigEndian.Uint32(data[4:8])
+	if version != 2 && version != 3 {
+		return nil, fmt.Errorf("unsupported index version %d", version)
+	}
+	count := binary.BigEndian.Uint32(data[8:12])
+	// data is whatever the remote server claims it is; a hostile one can
+	// set count near 0xFFFFFFFF to make us allocate a slice of hundreds of
+	// GB before the truncation check below ever runs. Every entry takes
+	// at least minIndexEntrySize bytes, so bound count by what the
+	// remaining data could actually hold.
+	const minIndexEntrySize = 62
+	if rem := len(data) - 12; count > uint32(rem/minIndexEntrySize) {
+		return nil, fmt.Errorf("index claims %d entries, more than %d remaining bytes could hold", count, rem)
+	}
+
+	entries := make([]indexEntry, 0, count)
+	off := 12
+	for i := uint32(0); i < count; i++ {
+		start := off
+		if start+62 > len(data) {
+			return nil, fmt.Errorf("truncated index entry %d", i)
+		}
+		sha1 := data[start+40 : start+60]
+		flags := binary.BigEndian.Uint16(data[start+60 : start+62])
+		nameLen := int(flags & 0x0FFF)
+		nameStart := start + 62
+		extended := flags&0x4000 != 0
+		if extended {
+			nameStart += 2
+		}
+		if nameStart+nameLen > len(data) {
+			return nil, fmt.Errorf("truncated index entry %d name", i)
+		}
+		name := string(data[nameStart : nameStart+nameLen])
+
+		// Entries are NUL-padded to a multiple of 8 bytes measured from
+		// the start of the entry.
+		entryLen := nameStart + nameLen - start
+		padded := ((entryLen + 8) / 8) * 8
+		off = start + padded
+
+		entries = append(entries, indexEntry{
+			SHA1: hex.EncodeToString(sha1),
+			Path: name,
+		})
+	}
+	return entries, nil
+}
@@ -0,0 +1,132 @@
+package dumper
+
+import (
+	"bufio"
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// maxInflatedObjectSize bounds how much decompressed data fetchLooseObject
+// will read out of a single loose object, since the compressed size on the
+// wire says nothing about how large a zlib bomb could inflate to.
+const maxInflatedObjectSize = 512 << 20 // 512MiB
+
+// walkObjects downloads every object reachable from seeds, recursively
+// enqueuing SHAs found by inflating loose objects and parsing commit/tree
+// bodies, and writes each one to objects/xx/yyyy... .
+func (d *Dumper) walkObjects(seeds []string) error {
+	queue := append([]string{}, seeds...)
+	for len(queue) > 0 {
+		sha := queue[0]
+		queue = queue[1:]
+		if len(sha) != 40 || d.seen[sha] {
+			continue
+		}
+		d.seen[sha] = true
+
+		refs, err := d.fetchLooseObject(sha)
+		if err != nil {
+			// Unreadable/missing objects are common when a server only
+			// partially exposes .git; skip and keep going.
+			continue
+		}
+		queue = append(queue, refs...)
+	}
+	return nil
+}
+
+// fetchLooseObject downloads objects/<sha[:2]>/<sha[2:]>, writes it
+// unmodified to disk, and returns the SHAs it references.
+func (d *Dumper) fetchLooseObject(sha string) ([]string, error) {
+	name := fmt.Sprintf("objects/%s/%s", sha[:2], sha[2:])
+	raw, err := d.fetcher.getBytes(name)
+	if err != nil {
+		return nil, err
+	}
+	dest := filepath.Join(d.opts.OutputDir, ".git", filepath.FromSlash(name))
+	if err := os.MkdirAll(filepath.Dir(dest), 0o755); err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(dest, raw, 0o644); err != nil {
+		return nil, err
+	}
+
+	zr, err := zlib.NewReader(bytes.NewReader(raw))
+	if err != nil {
+		return nil, fmt.Errorf("inflating %s: %w", sha, err)
+	}
+	defer zr.Close()
+	// raw came from the remote server, so its decompressed size is just
+	// as untrusted as its compressed size; cap the read so a small
+	// zlib-bombed object can't balloon into an unbounded allocation.
+	body, err := io.ReadAll(io.LimitReader(zr, maxInflatedObjectSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("reading inflated %s: %w", sha, err)
+	}
+	if len(body) > maxInflatedObjectSize {
+		return nil, fmt.Errorf("inflated %s exceeds %d bytes, refusing to continue", sha, maxInflatedObjectSize)
+	}
+
+	typ, content := splitObjectHeader(body)
+	switch typ {
+	case "commit":
+		return parseCommitRefs(content), nil
+	case "tree":
+		return parseTreeRefs(content), nil
+	default: // blob, tag
+		return nil, nil
+	}
+}
+
+// splitObjectHeader strips the "<type> <size>\0" header git prepends to
+// every inflated object.
+func splitObjectHeader(body []byte) (typ string, content []byte) {
+	i := bytes.IndexByte(body, 0)
+	if i < 0 {
+		return "", body
+	}
+	header := string(body[:i])
+	typ = header
+	if sp := strings.IndexByte(header, ' '); sp >= 0 {
+		typ = header[:sp]
+	}
+	return typ, body[i+1:]
+}
+
+// parseCommitRefs extracts the "tree" and "parent" SHAs from a commit body.
+func parseCommitRefs(content []byte) []string {
+	var shas []string
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" {
+			break // end of headers, start of commit message
+		}
+		if sha, ok := strings.CutPrefix(line, "tree "); ok {
+			shas = append(shas, sha)
+		} else if sha, ok := strings.CutPrefix(line, "parent "); ok {
+			shas = append(shas, sha)
+		}
+	}
+	return shas
+}
+
+// parseTreeRefs extracts every entry SHA from a tree object's binary body:
+// repeated "<mode> <name>\0<20-byte sha>" records.
+func parseTreeRefs(content []byte) []string {
+	var shas []string
+	for len(content) > 0 {
+		i := bytes.IndexByte(content, 0)
+		if i < 0 || i+21 > len(content) {
+			break
+		}
+		shas = append(shas, fmt.Sprintf("%x", content[i+1:i+21]))
+		content = content[i+21:]
+	}
+	return shas
+}
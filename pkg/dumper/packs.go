@@ -0,0 +1,33 @@
+package dumper
+
+import (
+	"bufio"
+	"strings"
+)
+
+// fetchPacks downloads objects/info/packs and every pack/idx pair it lists.
+// The packed objects themselves aren't unpacked; `git fsck`/`git checkout`
+// at the end of Dump reads them directly from objects/pack.
+func (d *Dumper) fetchPacks() error {
+	data, err := d.fetcher.getBytes("objects/info/packs")
+	if err != nil {
+		// Servers with directory listing disabled (the common case) don't
+		// serve this file at all; that's fine, loose objects still work.
+		return nil
+	}
+
+	scanner := bufio.NewScanner(strings.NewReader(string(data)))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) != 2 || fields[0] != "P" {
+			continue
+		}
+		base := strings.TrimSuffix(fields[1], ".pack")
+		for _, ext := range []string{".pack", ".idx"} {
+			if err := d.fetchToFile("objects/pack/" + base + ext); err != nil {
+				continue
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,101 @@
+package dumper
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// commonRefs is the same short-list of branch/ref names git-dumper probes,
+// since a plain directory listing is almost never available.
+var commonRefs = []string{
+	"refs/heads/master",
+	"refs/heads/main",
+	"refs/heads/develop",
+	"refs/heads/dev",
+	"refs/heads/staging",
+	"refs/remotes/origin/master",
+	"refs/remotes/origin/main",
+	"refs/stash",
+}
+
+var shaRE = regexp.MustCompile(`\b[0-9a-f]{40}\b`)
+
+// walkRefs follows HEAD (and the common ref list) down to concrete SHAs.
+func (d *Dumper) walkRefs() error {
+	headPath := filepath.Join(d.opts.OutputDir, ".git", "HEAD")
+	head, err := os.ReadFile(headPath)
+	if err != nil {
+		return fmt.Errorf("reading local HEAD: %w", err)
+	}
+	refs := append([]string{}, commonRefs...)
+	if ref := strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(string(head)), "ref:")); strings.HasPrefix(ref, "refs/") {
+		refs = append(refs, ref)
+	}
+	for _, ref := range refs {
+		if err := d.fetchToFile(ref); err != nil {
+			// Most refs in commonRefs won't exist; that's expected.
+			continue
+		}
+	}
+	return nil
+}
+
+// walkPackedRefs fetches nothing further itself; packed-refs SHAs are
+// collected by headSHAs once the file is on disk.
+func (d *Dumper) walkPackedRefs() error {
+	return nil
+}
+
+// walkLogs recovers refs/heads/* history recorded in logs/HEAD, which often
+// survives even when loose refs have been repacked away.
+func (d *Dumper) walkLogs() error {
+	if err := d.fetchToFile("logs/HEAD"); err != nil {
+		return nil
+	}
+	return nil
+}
+
+// headSHAs collects every SHA-1 mentioned in HEAD, refs/*, packed-refs, and
+// logs/HEAD as discovered so far, to seed the object walk.
+func (d *Dumper) headSHAs() []string {
+	var shas []string
+	gitDir := filepath.Join(d.opts.OutputDir, ".git")
+	for _, rel := range []string{"HEAD", "packed-refs", "logs/HEAD"} {
+		f, err := os.Open(filepath.Join(gitDir, rel))
+		if err != nil {
+			continue
+		}
+		scanner := bufio.NewScanner(f)
+		for scanner.Scan() {
+			shas = append(shas, shaRE.FindAllString(scanner.Text(), -1)...)
+		}
+		f.Close()
+	}
+	filepath.Walk(filepath.Join(gitDir, "refs"), func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return nil
+		}
+		data, err := os.ReadFile(path)
+		if err == nil {
+			shas = append(shas, shaRE.FindAllString(string(data), -1)...)
+		}
+		return nil
+	})
+	return dedupe(shas)
+}
+
+func dedupe(in []string) []string {
+	seen := make(map[string]bool, len(in))
+	out := make([]string, 0, len(in))
+	for _, s := range in {
+		if !seen[s] {
+			seen[s] = true
+			out = append(out, s)
+		}
+	}
+	return out
+}
@@ -0,0 +1,40 @@
+package events
+
+import (
+	"encoding/json"
+	"os"
+	"sync"
+)
+
+// jsonSink writes one JSON object per line to stdout, for tools that want
+// to consume gget's progress programmatically instead of scraping colored
+// text.
+type jsonSink struct {
+	mu sync.Mutex
+}
+
+type jsonLine struct {
+	Type string `json:"type"`
+	URL  string `json:"url,omitempty"`
+	Line string `json:"line,omitempty"`
+	BuildEvent
+}
+
+func (s *jsonSink) emit(v jsonLine) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	enc := json.NewEncoder(os.Stdout)
+	enc.Encode(v)
+}
+
+func (s *jsonSink) Build(e BuildEvent) {
+	s.emit(jsonLine{Type: "build", BuildEvent: e})
+}
+
+func (s *jsonSink) Stdout(url string, line []byte) {
+	s.emit(jsonLine{Type: "stdout", URL: url, Line: string(line)})
+}
+
+func (s *jsonSink) Stderr(url string, line []byte) {
+	s.emit(jsonLine{Type: "stderr", URL: url, Line: string(line)})
+}
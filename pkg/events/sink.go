@@ -0,0 +1,42 @@
+// Package events defines a pluggable sink for the build and run events gget
+// produces while driving Docker, so the same event stream can be rendered
+// as colored TTY output, plain text, or newline-delimited JSON.
+package events
+
+import "fmt"
+
+// BuildEvent is one line of a Docker image build's newline-delimited JSON
+// response.
+type BuildEvent struct {
+	Stream      string `json:"stream,omitempty"`
+	Status      string `json:"status,omitempty"`
+	Progress    string `json:"progress,omitempty"`
+	AuxID       string `json:"auxId,omitempty"`
+	Error       string `json:"error,omitempty"`
+	ErrorDetail string `json:"errorDetail,omitempty"`
+}
+
+// Sink receives build events and demultiplexed container log lines.
+// Implementations must be safe for concurrent use, since gget drives many
+// URLs at once.
+type Sink interface {
+	Build(e BuildEvent)
+	Stdout(url string, line []byte)
+	Stderr(url string, line []byte)
+}
+
+// New constructs the Sink named by format: "tty" (colored, the default),
+// "plain" (no color, for non-terminal stdout), or "json" (newline-delimited
+// JSON, for machine consumption).
+func New(format string) (Sink, error) {
+	switch format {
+	case "", "tty":
+		return &ttySink{}, nil
+	case "plain":
+		return &plainSink{}, nil
+	case "json":
+		return &jsonSink{}, nil
+	default:
+		return nil, fmt.Errorf("events: unknown -log-format %q, expected tty, plain, or json", format)
+	}
+}
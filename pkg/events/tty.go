@@ -0,0 +1,57 @@
+package events
+
+import (
+	"fmt"
+
+	"github.com/ttacon/chalk"
+)
+
+// ttySink renders events the way gget has always printed them: a white
+// label followed by a colored payload.
+type ttySink struct{}
+
+func (s *ttySink) Build(e BuildEvent) {
+	switch {
+	case e.Error != "":
+		fmt.Println(chalk.White.Color("(ERROR)"), chalk.Red.Color(e.ErrorDetail))
+	case e.Stream != "":
+		fmt.Println(chalk.White.Color("(STREAM)"), chalk.Green.Color(e.Stream))
+	case e.Status != "":
+		fmt.Println(chalk.White.Color("(STATUS)"), chalk.Green.Color(e.Status))
+	case e.Progress != "":
+		fmt.Println(chalk.White.Color("(PROGRESS)"), chalk.Green.Color(e.Progress))
+	}
+}
+
+func (s *ttySink) Stdout(url string, line []byte) {
+	fmt.Println(chalk.White.Color("(STDOUT)"), chalk.Green.Color(url), string(line))
+}
+
+func (s *ttySink) Stderr(url string, line []byte) {
+	fmt.Println(chalk.White.Color("(STDERR)"), chalk.Yellow.Color(url), string(line))
+}
+
+// plainSink is ttySink without ANSI color, for redirected/non-terminal
+// stdout where escape codes would just be noise.
+type plainSink struct{}
+
+func (s *plainSink) Build(e BuildEvent) {
+	switch {
+	case e.Error != "":
+		fmt.Println("(ERROR)", e.ErrorDetail)
+	case e.Stream != "":
+		fmt.Println("(STREAM)", e.Stream)
+	case e.Status != "":
+		fmt.Println("(STATUS)", e.Status)
+	case e.Progress != "":
+		fmt.Println("(PROGRESS)", e.Progress)
+	}
+}
+
+func (s *plainSink) Stdout(url string, line []byte) {
+	fmt.Println("(STDOUT)", url, string(line))
+}
+
+func (s *plainSink) Stderr(url string, line []byte) {
+	fmt.Println("(STDERR)", url, string(line))
+}
@@ -0,0 +1,114 @@
+// Package image renders the Dockerfile gget builds its worker image from,
+// templated by base image, proxy, and git-dumper version knobs, and caches
+// built images by a hash of the rendered build context.
+package image
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	_ "embed"
+	"fmt"
+	"io"
+	"text/template"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/docker/docker/client"
+)
+
+//go:embed dockerfile.tmpl
+var dockerfileTmpl string
+
+// Tag returns the image tag gget would build for opt: "gget:<contenthash>".
+// Two calls with equal options (after defaulting) return the same tag.
+func Tag(opt DockerfileOpt) (tag string, err error) {
+	rendered, err := render(opt.withDefaults())
+	if err != nil {
+		return "", err
+	}
+	return "gget:" + contentHash(rendered), nil
+}
+
+// Prepare renders the Dockerfile for opt and tars it into an in-memory
+// build context, returning the content-hash tag it should be built under.
+// It has no dependency on any particular container runtime client, so
+// every ContainerRuntime implementation can share it.
+func Prepare(opt DockerfileOpt) (tag string, buildContext *bytes.Buffer, err error) {
+	opt = opt.withDefaults()
+	rendered, err := render(opt)
+	if err != nil {
+		return "", nil, fmt.Errorf("image: rendering Dockerfile: %w", err)
+	}
+	tarBuf, err := tarContext(rendered)
+	if err != nil {
+		return "", nil, fmt.Errorf("image: building tar context: %w", err)
+	}
+	return "gget:" + contentHash(rendered), tarBuf, nil
+}
+
+// Build renders the Dockerfile for opt, and unless a matching image is
+// already cached (or noCache is set), builds it via the Docker Engine API.
+// It returns the image tag and the raw ImageBuild response body so the
+// caller can stream build events to its own events.Sink; the caller must
+// close the body.
+func Build(ctx context.Context, cli *client.Client, opt DockerfileOpt, noCache bool) (tag string, body io.ReadCloser, err error) {
+	tag, tarBuf, err := Prepare(opt)
+	if err != nil {
+		return "", nil, err
+	}
+
+	if !noCache {
+		cached, err := cli.ImageList(ctx, types.ImageListOptions{
+			Filters: filters.NewArgs(filters.Arg("reference", tag)),
+		})
+		if err == nil && len(cached) > 0 {
+			return tag, io.NopCloser(bytes.NewReader(nil)), nil
+		}
+	}
+
+	res, err := cli.ImageBuild(ctx, tarBuf, types.ImageBuildOptions{
+		Tags:      []string{tag},
+		BuildArgs: opt.buildArgs(),
+		NoCache:   noCache,
+	})
+	if err != nil {
+		return "", nil, fmt.Errorf("image: ImageBuild: %w", err)
+	}
+	return tag, res.Body, nil
+}
+
+// render executes the embedded Dockerfile template against opt.
+func render(opt DockerfileOpt) ([]byte, error) {
+	tmpl, err := template.New("Dockerfile").Parse(dockerfileTmpl)
+	if err != nil {
+		return nil, fmt.Errorf("parsing Dockerfile template: %w", err)
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, opt); err != nil {
+		return nil, fmt.Errorf("executing Dockerfile template: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// tarContext tars the rendered Dockerfile in-memory as the sole entry of a
+// Docker build context.
+func tarContext(dockerfile []byte) (*bytes.Buffer, error) {
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	hdr := &tar.Header{
+		Name: "Dockerfile",
+		Mode: 0o644,
+		Size: int64(len(dockerfile)),
+	}
+	if err := tw.WriteHeader(hdr); err != nil {
+		return nil, err
+	}
+	if _, err := tw.Write(dockerfile); err != nil {
+		return nil, err
+	}
+	if err := tw.Close(); err != nil {
+		return nil, err
+	}
+	return &buf, nil
+}
@@ -0,0 +1,14 @@
+package image
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+)
+
+// contentHash returns a short hex digest of b, used to tag images by the
+// content of their rendered build context so unchanged options reuse the
+// same cached image.
+func contentHash(b []byte) string {
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])[:12]
+}
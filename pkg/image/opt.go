@@ -0,0 +1,47 @@
+package image
+
+// DockerfileOpt controls how the gget build image is rendered. Zero values
+// fall back to sane defaults in Build.
+type DockerfileOpt struct {
+	// BaseImage is the FROM image. Defaults to "python:3.11-slim".
+	BaseImage string
+	// AptProxy, if set, is written to apt.conf.d so package installs go
+	// through an internal mirror.
+	AptProxy string
+	// PypiProxy, if set, is passed to pip as the index URL.
+	PypiProxy string
+	// HTTPProxy, HTTPSProxy, NoProxy are passed through as Docker build
+	// args (and therefore as the equivalent env vars during the build).
+	HTTPProxy  string
+	HTTPSProxy string
+	NoProxy    string
+	// GitDumperRef pins the git-dumper revision installed into the image.
+	// Defaults to "master".
+	GitDumperRef string
+}
+
+func (o DockerfileOpt) withDefaults() DockerfileOpt {
+	if o.BaseImage == "" {
+		o.BaseImage = "python:3.11-slim"
+	}
+	if o.GitDumperRef == "" {
+		o.GitDumperRef = "master"
+	}
+	return o
+}
+
+// buildArgs returns the BuildArgs map for client.ImageBuild. Empty values
+// are omitted so Docker falls back to the Dockerfile's own ARG defaults.
+func (o DockerfileOpt) buildArgs() map[string]*string {
+	args := map[string]*string{}
+	set := func(key, val string) {
+		if val != "" {
+			args[key] = &val
+		}
+	}
+	set("HTTP_PROXY", o.HTTPProxy)
+	set("HTTPS_PROXY", o.HTTPSProxy)
+	set("NO_PROXY", o.NoProxy)
+	set("GIT_DUMPER_REF", o.GitDumperRef)
+	return args
+}
@@ -0,0 +1,83 @@
+package runtime
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/mount"
+	"github.com/docker/docker/client"
+	"github.com/isolateminds/gget/pkg/image"
+)
+
+// dockerRuntime drives a local or remote Docker daemon via the standard
+// Engine API client.
+type dockerRuntime struct {
+	cli    *client.Client
+	output string
+}
+
+// NewDocker constructs a ContainerRuntime backed by the Docker Engine API,
+// configured the same way the docker CLI is (DOCKER_HOST, DOCKER_TLS_*, ...).
+func NewDocker(outputDir string) (ContainerRuntime, error) {
+	cli, err := client.NewClientWithOpts(client.FromEnv)
+	if err != nil {
+		return nil, fmt.Errorf("runtime: creating docker client: %w", err)
+	}
+	return &dockerRuntime{cli: cli, output: outputDir}, nil
+}
+
+func (d *dockerRuntime) BuildImage(ctx context.Context, opt image.DockerfileOpt, noCache bool) (string, io.ReadCloser, error) {
+	return image.Build(ctx, d.cli, opt, noCache)
+}
+
+func (d *dockerRuntime) CreateContainer(ctx context.Context, tag, hostname, gitUrl string) (string, error) {
+	body, err := d.cli.ContainerCreate(
+		ctx,
+		&container.Config{
+			Image:        tag,
+			AttachStdout: true,
+			AttachStderr: true,
+			User:         "gget",
+			//The entrypoint here is actually the execution of the git-dumper command
+			Cmd: []string{"git-dumper", gitUrl, fmt.Sprintf("/home/gget/%s", hostname)},
+		},
+		&container.HostConfig{
+			Mounts: []mount.Mount{
+				{
+					Type:   mount.TypeBind,
+					Source: d.output,
+					Target: "/home/gget",
+				},
+			},
+		},
+		nil,
+		nil,
+		hostname,
+	)
+	if err != nil {
+		return "", err
+	}
+	return body.ID, nil
+}
+
+func (d *dockerRuntime) StartContainer(ctx context.Context, id string) error {
+	return d.cli.ContainerStart(ctx, id, types.ContainerStartOptions{})
+}
+
+func (d *dockerRuntime) Logs(ctx context.Context, id string) (io.ReadCloser, error) {
+	return d.cli.ContainerLogs(ctx, id, types.ContainerLogsOptions{
+		Follow:     true,
+		ShowStdout: true,
+		ShowStderr: true,
+	})
+}
+
+func (d *dockerRuntime) Remove(ctx context.Context, id string) error {
+	return d.cli.ContainerRemove(ctx, id, types.ContainerRemoveOptions{
+		RemoveVolumes: true,
+		Force:         true,
+	})
+}
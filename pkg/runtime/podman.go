@@ -0,0 +1,189 @@
+package runtime
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+
+	"github.com/isolateminds/gget/pkg/image"
+)
+
+// podmanAPIVersion is the libpod REST API version gget targets.
+const podmanAPIVersion = "v4.0.0"
+
+// podmanRuntime drives a rootless Podman daemon over its libpod REST API,
+// reached over a unix socket rather than TCP.
+type podmanRuntime struct {
+	http   *http.Client
+	output string
+}
+
+// NewPodman constructs a ContainerRuntime backed by the Podman REST API,
+// honoring CONTAINER_HOST or the rootless default socket under
+// $XDG_RUNTIME_DIR/podman/podman.sock.
+func NewPodman(outputDir string) (ContainerRuntime, error) {
+	sockPath, ok := resolvePodmanSocket()
+	if !ok {
+		return nil, fmt.Errorf("runtime: no reachable podman socket (set CONTAINER_HOST or run `podman system service`)")
+	}
+	httpClient := &http.Client{
+		Transport: &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				return (&net.Dialer{}).DialContext(ctx, "unix", sockPath)
+			},
+		},
+	}
+	return &podmanRuntime{http: httpClient, output: outputDir}, nil
+}
+
+// req issues an HTTP request against the libpod API. query is optional and
+// URL-encoded onto the request; the response body is returned unread so
+// callers can either stream it (BuildImage) or decode it (everything else).
+func (p *podmanRuntime) req(ctx context.Context, method, path string, query url.Values, body io.Reader) (*http.Response, error) {
+	u := "http://d/" + podmanAPIVersion + "/libpod" + path
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, method, u, body)
+	if err != nil {
+		return nil, err
+	}
+	res, err := p.http.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("podman API %s %s: %w", method, path, err)
+	}
+	if res.StatusCode >= 300 {
+		defer res.Body.Close()
+		msg, _ := io.ReadAll(res.Body)
+		return nil, fmt.Errorf("podman API %s %s: %s: %s", method, path, res.Status, string(msg))
+	}
+	return res, nil
+}
+
+// BuildImage renders opt into a Dockerfile with pkg/image (which has no
+// runtime-specific dependency) and posts the resulting tar as the build
+// context, exactly like the Docker Engine API's /build endpoint. Unless
+// noCache is set, it first checks the local Podman image store for the
+// content-hash tag image.Prepare would build and skips the build entirely
+// on a hit, mirroring the cache check image.Build does for Docker.
+func (p *podmanRuntime) BuildImage(ctx context.Context, opt image.DockerfileOpt, noCache bool) (string, io.ReadCloser, error) {
+	tag, tarBuf, err := image.Prepare(opt)
+	if err != nil {
+		return "", nil, err
+	}
+
+	if !noCache && p.imageExists(ctx, tag) {
+		return tag, io.NopCloser(bytes.NewReader(nil)), nil
+	}
+
+	query := url.Values{"t": {tag}}
+	if noCache {
+		query.Set("nocache", "true")
+	}
+	res, err := p.req(ctx, http.MethodPost, "/build", query, tarBuf)
+	if err != nil {
+		return "", nil, err
+	}
+	return tag, res.Body, nil
+}
+
+// imageExists reports whether tag is already present in the local Podman
+// image store.
+func (p *podmanRuntime) imageExists(ctx context.Context, tag string) bool {
+	res, err := p.req(ctx, http.MethodGet, "/images/"+url.PathEscape(tag)+"/exists", nil, nil)
+	if err != nil {
+		return false
+	}
+	res.Body.Close()
+	return true
+}
+
+// createContainerBody is the subset of libpod's SpecGenerator gget needs.
+// Rootless Podman maps container UIDs into the invoking user's subuid
+// range, so unlike Docker's dockerRuntime this deliberately does not set a
+// User: the image's own USER instruction already resolves correctly inside
+// that mapping, and overriding it here would fight the mapping instead.
+type createContainerBody struct {
+	Image   string   `json:"image"`
+	Name    string   `json:"name"`
+	Command []string `json:"command"`
+	Mounts  []struct {
+		Destination string   `json:"destination"`
+		Source      string   `json:"source"`
+		Type        string   `json:"type"`
+		Options     []string `json:"options"`
+	} `json:"mounts"`
+}
+
+func (p *podmanRuntime) CreateContainer(ctx context.Context, tag, hostname, gitUrl string) (string, error) {
+	body := createContainerBody{
+		Image:   tag,
+		Name:    hostname,
+		Command: []string{"git-dumper", gitUrl, fmt.Sprintf("/home/gget/%s", hostname)},
+	}
+	body.Mounts = []struct {
+		Destination string   `json:"destination"`
+		Source      string   `json:"source"`
+		Type        string   `json:"type"`
+		Options     []string `json:"options"`
+	}{
+		{
+			Destination: "/home/gget",
+			Source:      p.output,
+			Type:        "bind",
+			// "Z" relabels the mount for a private SELinux label, which
+			// rootless Podman needs where Docker's shared-daemon model
+			// does not.
+			Options: []string{"rw", "Z"},
+		},
+	}
+	data, err := json.Marshal(body)
+	if err != nil {
+		return "", err
+	}
+	res, err := p.req(ctx, http.MethodPost, "/containers/create", nil, bytes.NewReader(data))
+	if err != nil {
+		return "", err
+	}
+	defer res.Body.Close()
+	var created struct {
+		ID string `json:"Id"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&created); err != nil {
+		return "", err
+	}
+	return created.ID, nil
+}
+
+func (p *podmanRuntime) StartContainer(ctx context.Context, id string) error {
+	res, err := p.req(ctx, http.MethodPost, "/containers/"+id+"/start", nil, nil)
+	if err != nil {
+		return err
+	}
+	res.Body.Close()
+	return nil
+}
+
+func (p *podmanRuntime) Logs(ctx context.Context, id string) (io.ReadCloser, error) {
+	query := url.Values{"stdout": {"true"}, "stderr": {"true"}, "follow": {"true"}, "stream": {"true"}}
+	res, err := p.req(ctx, http.MethodGet, "/containers/"+id+"/logs", query, nil)
+	if err != nil {
+		return nil, err
+	}
+	return res.Body, nil
+}
+
+func (p *podmanRuntime) Remove(ctx context.Context, id string) error {
+	query := url.Values{"force": {"true"}, "v": {"true"}}
+	res, err := p.req(ctx, http.MethodDelete, "/containers/"+id, query, nil)
+	if err != nil {
+		return err
+	}
+	res.Body.Close()
+	return nil
+}
@@ -0,0 +1,70 @@
+// Package runtime abstracts gget's direct dependency on the Docker Engine
+// client behind a ContainerRuntime interface, so it can also drive rootless
+// Podman over its REST API socket.
+package runtime
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strings"
+
+	"github.com/isolateminds/gget/pkg/image"
+)
+
+// ContainerRuntime builds the gget worker image and drives a single
+// container through its lifecycle. gitUrl is passed through to the
+// container's command line; hostname is both the container name and the
+// subdirectory of the output mount git-dumper writes into.
+type ContainerRuntime interface {
+	BuildImage(ctx context.Context, opt image.DockerfileOpt, noCache bool) (tag string, body io.ReadCloser, err error)
+	CreateContainer(ctx context.Context, tag, hostname, gitUrl string) (id string, err error)
+	StartContainer(ctx context.Context, id string) error
+	Logs(ctx context.Context, id string) (io.ReadCloser, error)
+	Remove(ctx context.Context, id string) error
+}
+
+// New constructs the ContainerRuntime named by name: "docker", "podman", or
+// "" / "auto" to probe for a reachable rootless Podman socket before
+// falling back to Docker. outputDir is bind-mounted into every container
+// this runtime creates.
+func New(name string, outputDir string) (ContainerRuntime, error) {
+	switch name {
+	case "docker":
+		return NewDocker(outputDir)
+	case "podman":
+		return NewPodman(outputDir)
+	case "", "auto":
+		if _, ok := resolvePodmanSocket(); ok {
+			if rt, err := NewPodman(outputDir); err == nil {
+				return rt, nil
+			}
+		}
+		return NewDocker(outputDir)
+	default:
+		return nil, fmt.Errorf("runtime: unknown -runtime %q, expected docker or podman", name)
+	}
+}
+
+// resolvePodmanSocket finds and dials the Podman REST API socket, honoring
+// CONTAINER_HOST and the rootless default under $XDG_RUNTIME_DIR.
+func resolvePodmanSocket() (string, bool) {
+	path := os.Getenv("CONTAINER_HOST")
+	if path == "" {
+		if dir := os.Getenv("XDG_RUNTIME_DIR"); dir != "" {
+			path = dir + "/podman/podman.sock"
+		}
+	}
+	path = strings.TrimPrefix(path, "unix://")
+	if path == "" {
+		return "", false
+	}
+	conn, err := net.Dial("unix", path)
+	if err != nil {
+		return "", false
+	}
+	conn.Close()
+	return path, true
+}
@@ -0,0 +1,75 @@
+package scheduler
+
+import (
+	"fmt"
+	"sync"
+)
+
+// Status is a URL's current position in the pipeline.
+type Status int
+
+const (
+	StatusFetching Status = iota
+	StatusTesting
+	StatusDone
+	StatusFailed
+)
+
+func (s Status) String() string {
+	switch s {
+	case StatusFetching:
+		return "FETCHING"
+	case StatusTesting:
+		return "TESTING"
+	case StatusDone:
+		return "DONE"
+	case StatusFailed:
+		return "FAILED"
+	default:
+		return "UNKNOWN"
+	}
+}
+
+// Reporter receives status transitions for individual URLs. Implementations
+// typically aggregate these into a single live status line rather than
+// printing one line per URL, since a large -f list would otherwise scroll
+// the terminal illegibly.
+type Reporter interface {
+	Report(url string, status Status)
+}
+
+// NopReporter discards every report.
+type NopReporter struct{}
+
+// Report implements Reporter.
+func (NopReporter) Report(string, Status) {}
+
+// AggregateReporter renders a single live line with the current count of
+// URLs sitting in each status, overwriting itself with a carriage return on
+// each update.
+type AggregateReporter struct {
+	mu     sync.Mutex
+	counts map[Status]int
+	last   map[string]Status
+}
+
+// NewAggregateReporter constructs an AggregateReporter.
+func NewAggregateReporter() *AggregateReporter {
+	return &AggregateReporter{counts: make(map[Status]int), last: make(map[string]Status)}
+}
+
+// Report implements Reporter. Each URL only ever occupies one bucket at a
+// time: moving it to a new status decrements its previous bucket first, so
+// the printed counts reflect current state rather than a running total of
+// every report ever made for that URL.
+func (a *AggregateReporter) Report(url string, status Status) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if prev, ok := a.last[url]; ok {
+		a.counts[prev]--
+	}
+	a.last[url] = status
+	a.counts[status]++
+	fmt.Printf("\r(FETCHING) %d  (TESTING) %d  (DONE) %d  (FAILED) %d",
+		a.counts[StatusFetching], a.counts[StatusTesting], a.counts[StatusDone], a.counts[StatusFailed])
+}
@@ -0,0 +1,147 @@
+// Package scheduler runs a bounded pool of workers over a list of URLs,
+// retrying transient failures with backoff and persisting progress to a
+// resumable state file so a killed run can be re-invoked without redoing
+// finished work.
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Task performs the work for a single URL. A non-nil error that satisfies
+// `interface{ Temporary() bool }` with Temporary() == true is retried;
+// anything else is treated as a permanent failure.
+type Task func(ctx context.Context, url string) error
+
+// Options configures a Scheduler.
+type Options struct {
+	// Concurrency is the number of URLs processed at once. Defaults to 1.
+	Concurrency int
+	// MaxRetries is the number of retry attempts for transient failures,
+	// not counting the initial attempt. Defaults to 3.
+	MaxRetries int
+	// StateFile, if set, records completed URLs so a re-invocation of the
+	// same file skips them. An empty path disables resume support.
+	StateFile string
+	// Reporter receives status transitions as they happen. Defaults to a
+	// no-op reporter.
+	Reporter Reporter
+}
+
+// Scheduler runs a Task over many URLs with bounded concurrency.
+type Scheduler struct {
+	opts  Options
+	task  Task
+	state *state
+}
+
+// New constructs a Scheduler. If opts.StateFile is set and already exists,
+// its completed-URL set is loaded immediately so Run can skip them.
+func New(task Task, opts Options) (*Scheduler, error) {
+	if opts.Concurrency <= 0 {
+		opts.Concurrency = 1
+	}
+	if opts.MaxRetries <= 0 {
+		opts.MaxRetries = 3
+	}
+	if opts.Reporter == nil {
+		opts.Reporter = NopReporter{}
+	}
+	st, err := loadState(opts.StateFile)
+	if err != nil {
+		return nil, fmt.Errorf("scheduler: loading state file: %w", err)
+	}
+	return &Scheduler{opts: opts, task: task, state: st}, nil
+}
+
+// Run processes every URL, skipping ones already marked complete in the
+// state file, and returns the URLs that failed permanently.
+func (s *Scheduler) Run(ctx context.Context, urls []string) (failed []string) {
+	jobs := make(chan string)
+	results := make(chan result)
+
+	var wg sync.WaitGroup
+	wg.Add(s.opts.Concurrency)
+	for i := 0; i < s.opts.Concurrency; i++ {
+		go func() {
+			defer wg.Done()
+			for url := range jobs {
+				results <- result{url: url, err: s.runWithRetry(ctx, url)}
+			}
+		}()
+	}
+
+	go func() {
+		defer close(jobs)
+		for _, url := range urls {
+			if s.state.isDone(url) {
+				s.opts.Reporter.Report(url, StatusDone)
+				continue
+			}
+			select {
+			case jobs <- url:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	for r := range results {
+		if r.err != nil {
+			s.opts.Reporter.Report(r.url, StatusFailed)
+			failed = append(failed, r.url)
+			continue
+		}
+		s.opts.Reporter.Report(r.url, StatusDone)
+		s.state.markDone(r.url)
+		s.state.save(s.opts.StateFile)
+	}
+	return failed
+}
+
+type result struct {
+	url string
+	err error
+}
+
+// runWithRetry invokes the Task, retrying on transient errors with
+// exponential backoff (1s, 2s, 4s, ...) up to MaxRetries attempts.
+func (s *Scheduler) runWithRetry(ctx context.Context, url string) error {
+	s.opts.Reporter.Report(url, StatusFetching)
+	var err error
+	for attempt := 0; attempt <= s.opts.MaxRetries; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-time.After(time.Second << (attempt - 1)):
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+		err = s.task(ctx, url)
+		if err == nil {
+			return nil
+		}
+		if !isTemporary(err) {
+			return err
+		}
+	}
+	return err
+}
+
+// temporary is implemented by errors that the scheduler may retry.
+type temporary interface {
+	Temporary() bool
+}
+
+func isTemporary(err error) bool {
+	t, ok := err.(temporary)
+	return ok && t.Temporary()
+}
@@ -0,0 +1,63 @@
+package scheduler
+
+import (
+	"encoding/json"
+	"os"
+	"sync"
+)
+
+// state is the on-disk record of which URLs a run has already completed,
+// so a killed invocation can be resumed without redoing finished work.
+type state struct {
+	mu   sync.Mutex
+	Done map[string]bool `json:"done"`
+}
+
+// loadState reads path if it exists, or returns an empty state if path is
+// empty or the file is not present yet.
+func loadState(path string) (*state, error) {
+	s := &state{Done: make(map[string]bool)}
+	if path == "" {
+		return s, nil
+	}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, s); err != nil {
+		return nil, err
+	}
+	if s.Done == nil {
+		s.Done = make(map[string]bool)
+	}
+	return s, nil
+}
+
+func (s *state) isDone(url string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.Done[url]
+}
+
+func (s *state) markDone(url string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.Done[url] = true
+}
+
+// save persists the state to path. A no-op when path is empty.
+func (s *state) save(path string) error {
+	if path == "" {
+		return nil
+	}
+	s.mu.Lock()
+	data, err := json.Marshal(s)
+	s.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o644)
+}